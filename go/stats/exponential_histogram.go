@@ -0,0 +1,241 @@
+/*
+Copyright 2019 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+)
+
+// defaultNativeMaxBuckets bounds the number of populated buckets a
+// NativeHistogram keeps when the caller does not specify one.
+const defaultNativeMaxBuckets = 160
+
+// nativeZeroThreshold is the absolute value below which an observation is
+// counted in the zero bucket rather than assigned to a positive or
+// negative bucket.
+const nativeZeroThreshold = 1e-9
+
+// NativeHistogram is a Prometheus-style native (exponential) histogram.
+// Unlike the fixed bucketCutoffs used elsewhere in this package, bucket
+// boundaries grow geometrically from a base of 2^(2^-schema), so a single
+// histogram keeps useful resolution across observations that span
+// microseconds to minutes instead of only within a hand-picked range.
+//
+// Observations are bucketed by index: for v > 0, idx = ceil(log2(v) *
+// 2^schema). Buckets are kept in sparse maps so that only populated
+// regions of the range use memory. When the number of populated buckets
+// exceeds maxBuckets, the schema is decremented and adjacent buckets
+// (index 2k-1 and 2k) are merged into index k, i.e. ceil(i/2), at the new
+// schema -- the standard native-histogram "scale reduction" step. The
+// schema is not reduced below -4, so with a very small maxBuckets the
+// number of populated buckets can stay above it. This is idempotent and
+// preserves the total count and sum.
+type NativeHistogram struct {
+	mu sync.Mutex
+
+	schema     int8
+	maxBuckets int
+
+	count     int64
+	sum       int64
+	zeroCount int64
+
+	// positive and negative hold per-bucket counts keyed by bucket index.
+	// Vitess only ever records non-negative durations, so negative stays
+	// empty in practice, but it is kept for parity with the Prometheus
+	// native histogram spec.
+	positive map[int32]int64
+	negative map[int32]int64
+}
+
+// NewNativeHistogram creates an empty NativeHistogram starting at the
+// given schema (roughly -4..8; higher values mean finer resolution) and
+// bounded to maxBuckets populated buckets before resolution is halved
+// (down to schema -4 at the coarsest).
+func NewNativeHistogram(schema int8, maxBuckets int) *NativeHistogram {
+	if maxBuckets <= 0 {
+		maxBuckets = defaultNativeMaxBuckets
+	}
+	return &NativeHistogram{
+		schema:     schema,
+		maxBuckets: maxBuckets,
+		positive:   make(map[int32]int64),
+		negative:   make(map[int32]int64),
+	}
+}
+
+// Add records a single observation.
+func (h *NativeHistogram) Add(v int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count++
+	h.sum += v
+
+	f := float64(v)
+	switch {
+	case math.Abs(f) < nativeZeroThreshold:
+		h.zeroCount++
+	case f > 0:
+		h.positive[h.indexLocked(f)]++
+		h.reduceResolutionLocked()
+	default:
+		h.negative[h.indexLocked(-f)]++
+		h.reduceResolutionLocked()
+	}
+}
+
+// Count returns the total number of observations.
+func (h *NativeHistogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+// Sum returns the sum of all observations.
+func (h *NativeHistogram) Sum() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.sum
+}
+
+// Quantile returns an estimate of the q-th quantile (0 <= q <= 1) by
+// walking the populated buckets in increasing order of observed value and
+// linearly interpolating within the bucket that contains the
+// q*count-th observation.
+func (h *NativeHistogram) Quantile(q float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+	target := q * float64(h.count)
+
+	buckets := make([]nativeBucket, 0, len(h.positive)+len(h.negative))
+	for idx, c := range h.negative {
+		buckets = append(buckets, nativeBucket{idx: idx, count: c, neg: true})
+	}
+	for idx, c := range h.positive {
+		buckets = append(buckets, nativeBucket{idx: idx, count: c})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].sortKey() < buckets[j].sortKey()
+	})
+
+	base := math.Pow(2, math.Ldexp(1, -int(h.schema)))
+
+	var cumulative float64
+	zeroPending := h.zeroCount > 0
+	for _, b := range buckets {
+		// The zero bucket sits between the negative and positive buckets.
+		if zeroPending && !b.neg {
+			zeroPending = false
+			cumulative += float64(h.zeroCount)
+			if cumulative >= target {
+				return 0
+			}
+		}
+		if cumulative+float64(b.count) >= target {
+			lower := math.Pow(base, float64(b.idx-1))
+			upper := math.Pow(base, float64(b.idx))
+			if b.neg {
+				lower, upper = -upper, -lower
+			}
+			frac := (target - cumulative) / float64(b.count)
+			return lower + frac*(upper-lower)
+		}
+		cumulative += float64(b.count)
+	}
+	if zeroPending && cumulative+float64(h.zeroCount) >= target {
+		return 0
+	}
+
+	if len(buckets) == 0 {
+		return 0
+	}
+	last := buckets[len(buckets)-1]
+	return math.Pow(base, float64(last.idx))
+}
+
+// indexLocked returns the bucket index for a positive value v under the
+// current schema: idx = ceil(log2(v) * 2^schema).
+func (h *NativeHistogram) indexLocked(v float64) int32 {
+	return int32(math.Ceil(math.Log2(v) * math.Ldexp(1, int(h.schema))))
+}
+
+// reduceResolutionLocked halves the resolution (decrements schema and
+// merges adjacent buckets) until the number of populated buckets is at or
+// below maxBuckets, or the schema reaches -4, the coarsest native-histogram
+// schema. It is idempotent and preserves total count and sum.
+func (h *NativeHistogram) reduceResolutionLocked() {
+	for len(h.positive)+len(h.negative) > h.maxBuckets && h.schema > -4 {
+		h.positive = collapseNativeBuckets(h.positive)
+		h.negative = collapseNativeBuckets(h.negative)
+		h.schema--
+	}
+}
+
+// collapseNativeBuckets halves the resolution of buckets by merging pairs
+// of adjacent indexes into the index a fresh observation would land in at
+// the reduced schema. Since indexLocked assigns bucket idx to the interval
+// (base^(idx-1), base^idx] (a ceil, not a floor), the matching downscale
+// pairing is (2k-1, 2k) -> k, i.e. ceil(idx/2), not floor(idx/2).
+func collapseNativeBuckets(buckets map[int32]int64) map[int32]int64 {
+	merged := make(map[int32]int64, len(buckets)/2+1)
+	for idx, count := range buckets {
+		merged[int32(math.Ceil(float64(idx)/2))] += count
+	}
+	return merged
+}
+
+// MarshalJSON implements json.Marshaler so NativeHistogram values embed
+// cleanly in the expvar output produced by Timings.String.
+func (h *NativeHistogram) MarshalJSON() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return json.Marshal(struct {
+		Count     int64
+		Sum       int64
+		ZeroCount int64
+		Schema    int8
+		Positive  map[int32]int64 `json:",omitempty"`
+		Negative  map[int32]int64 `json:",omitempty"`
+	}{h.count, h.sum, h.zeroCount, h.schema, h.positive, h.negative})
+}
+
+type nativeBucket struct {
+	idx   int32
+	count int64
+	neg   bool
+}
+
+// sortKey orders buckets by increasing observed value: negative buckets by
+// decreasing magnitude, then positive buckets by increasing magnitude.
+// Non-zero int64 observations have magnitude >= 1 and so index >= 0;
+// negative keys are shifted down by one so that they stay below every
+// positive key, including at index 0.
+func (b nativeBucket) sortKey() float64 {
+	if b.neg {
+		return -float64(b.idx) - 1
+	}
+	return float64(b.idx)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNativeHistogramCountAndSum(t *testing.T) {
+	h := NewNativeHistogram(2, 160)
+	values := []int64{1000, 2000, 3000, 4000, 5000}
+	var wantSum int64
+	for _, v := range values {
+		h.Add(v)
+		wantSum += v
+	}
+
+	assert.EqualValues(t, len(values), h.Count())
+	assert.EqualValues(t, wantSum, h.Sum())
+}
+
+func TestNativeHistogramQuantile(t *testing.T) {
+	h := NewNativeHistogram(5, 160)
+	for i := 1; i <= 1000; i++ {
+		h.Add(int64(i) * 1e6) // 1ms .. 1000ms
+	}
+
+	p50 := h.Quantile(0.5)
+	p99 := h.Quantile(0.99)
+
+	// With 1000 evenly spaced 1ms..1000ms observations, p50 should land
+	// near 500ms and p99 near 990ms; native histogram bucketing only
+	// guarantees an approximation, so allow a generous margin.
+	assert.InDelta(t, 500e6, p50, 50e6)
+	assert.InDelta(t, 990e6, p99, 50e6)
+	assert.Less(t, p50, p99)
+}
+
+func TestNativeHistogramZeroBucket(t *testing.T) {
+	h := NewNativeHistogram(2, 160)
+	h.Add(0)
+	h.Add(0)
+
+	require.EqualValues(t, 2, h.Count())
+	assert.Zero(t, h.Quantile(0.5))
+}
+
+func TestNativeHistogramReducesResolution(t *testing.T) {
+	h := NewNativeHistogram(8, 4)
+	for i := int64(1); i <= 1000; i++ {
+		h.Add(i * 1e3)
+	}
+
+	h.mu.Lock()
+	buckets := len(h.positive) + len(h.negative)
+	schema := h.schema
+	h.mu.Unlock()
+
+	assert.LessOrEqual(t, buckets, 4)
+	assert.Less(t, schema, int8(8))
+	assert.EqualValues(t, 1000, h.Count())
+}
+
+// TestNativeHistogramQuantileAfterMultipleReductions guards against a
+// regression in collapseNativeBuckets where the downscale merge used
+// floor(idx/2) instead of ceil(idx/2): since indexLocked assigns idx to
+// the interval (base^(idx-1), base^idx], only ceil pairs each merged
+// bucket with the index a fresh observation would actually land in at the
+// reduced schema. A low maxBuckets forces several reductions here, and the
+// resulting p50 is checked against the true median with a tight tolerance
+// that the floor-based merge fails.
+func TestNativeHistogramQuantileAfterMultipleReductions(t *testing.T) {
+	h := NewNativeHistogram(8, 6)
+	for i := int64(1); i <= 5000; i++ {
+		h.Add(i)
+	}
+
+	h.mu.Lock()
+	schema := h.schema
+	h.mu.Unlock()
+	require.Less(t, schema, int8(4), "expected several resolution reductions from schema 8")
+
+	assert.InDelta(t, 2500, h.Quantile(0.5), 2500*0.05)
+	assert.EqualValues(t, 5000, h.Count())
+}
+
+func TestTimingsExponential(t *testing.T) {
+	ts := NewExponentialTimings("", "help", "label", 3, 160)
+
+	ts.Add("a", 1)
+	ts.Add("a", 2)
+	ts.Add("b", 3)
+
+	assert.EqualValues(t, 3, ts.Count())
+	native := ts.NativeHistograms()
+	require.Contains(t, native, "a")
+	require.Contains(t, native, "b")
+	assert.EqualValues(t, 2, native["a"].Count())
+	assert.EqualValues(t, 1, native["b"].Count())
+
+	// The fixed-bucket surface stays usable (and empty) in native mode.
+	assert.Empty(t, ts.Histograms())
+}
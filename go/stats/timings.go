@@ -33,6 +33,13 @@ type Timings struct {
 	mu         sync.RWMutex
 	histograms map[string]*Histogram
 
+	// native, when set, routes Add through nativeHistograms instead of
+	// histograms: see NewExponentialTimings.
+	native           bool
+	nativeSchema     int8
+	nativeMaxBuckets int
+	nativeHistograms map[string]*NativeHistogram
+
 	name          string
 	help          string
 	label         string
@@ -61,11 +68,40 @@ func NewTimings(name, help, label string, categories ...string) *Timings {
 	return t
 }
 
+// NewExponentialTimings creates a new Timings object whose per-category
+// histograms are Prometheus-style native (exponential) histograms instead
+// of the fixed bucketCutoffs used by NewTimings. schema sets the initial
+// bucket resolution (roughly -4..8, higher is finer) and maxBuckets bounds
+// the number of populated buckets per category before resolution is
+// halved; see NativeHistogram for details. This is useful for workloads
+// whose latencies span a much wider dynamic range than bucketCutoffs
+// covers, at the cost of histograms that can't be compared directly
+// against ones using fixed cutoffs.
+func NewExponentialTimings(name, help, label string, schema int8, maxBuckets int) *Timings {
+	t := &Timings{
+		histograms:       make(map[string]*Histogram),
+		native:           true,
+		nativeSchema:     schema,
+		nativeMaxBuckets: maxBuckets,
+		nativeHistograms: make(map[string]*NativeHistogram),
+		name:             name,
+		help:             help,
+		label:            label,
+		labelCombined:    IsDimensionCombined(label),
+	}
+	if name != "" {
+		publish(name, t)
+	}
+
+	return t
+}
+
 // Reset will clearStats histograms: used during testing
 func (t *Timings) Reset() {
-	t.mu.RLock()
+	t.mu.Lock()
 	t.histograms = make(map[string]*Histogram)
-	t.mu.RUnlock()
+	t.nativeHistograms = make(map[string]*NativeHistogram)
+	t.mu.Unlock()
 }
 
 // Add will add a new value to the named histogram.
@@ -73,6 +109,23 @@ func (t *Timings) Add(name string, elapsed time.Duration) {
 	if t.labelCombined {
 		name = StatsAllStr
 	}
+	elapsedNs := int64(elapsed)
+
+	if t.native {
+		t.addNative(name, elapsedNs)
+	} else {
+		t.addFixed(name, elapsedNs)
+	}
+
+	if defaultStatsdHook.timerHook != nil && t.name != "" {
+		defaultStatsdHook.timerHook(t.name, name, elapsed.Milliseconds(), t)
+	}
+
+	t.totalCount.Add(1)
+	t.totalTime.Add(elapsedNs)
+}
+
+func (t *Timings) addFixed(name string, elapsedNs int64) {
 	// Get existing Histogram.
 	t.mu.RLock()
 	hist, ok := t.histograms[name]
@@ -88,14 +141,24 @@ func (t *Timings) Add(name string, elapsed time.Duration) {
 		}
 		t.mu.Unlock()
 	}
-	if defaultStatsdHook.timerHook != nil && t.name != "" {
-		defaultStatsdHook.timerHook(t.name, name, elapsed.Milliseconds(), t)
-	}
+	hist.Add(elapsedNs)
+}
 
-	elapsedNs := int64(elapsed)
+func (t *Timings) addNative(name string, elapsedNs int64) {
+	t.mu.RLock()
+	hist, ok := t.nativeHistograms[name]
+	t.mu.RUnlock()
+
+	if !ok {
+		t.mu.Lock()
+		hist, ok = t.nativeHistograms[name]
+		if !ok {
+			hist = NewNativeHistogram(t.nativeSchema, t.nativeMaxBuckets)
+			t.nativeHistograms[name] = hist
+		}
+		t.mu.Unlock()
+	}
 	hist.Add(elapsedNs)
-	t.totalCount.Add(1)
-	t.totalTime.Add(elapsedNs)
 }
 
 // Record is a convenience function that records completion
@@ -113,13 +176,15 @@ func (t *Timings) String() string {
 	defer t.mu.RUnlock()
 
 	tm := struct {
-		TotalCount int64
-		TotalTime  int64
-		Histograms map[string]*Histogram
+		TotalCount       int64
+		TotalTime        int64
+		Histograms       map[string]*Histogram
+		NativeHistograms map[string]*NativeHistogram `json:",omitempty"`
 	}{
 		t.totalCount.Load(),
 		t.totalTime.Load(),
 		t.histograms,
+		t.nativeHistograms,
 	}
 
 	data, err := json.Marshal(tm)
@@ -140,6 +205,19 @@ func (t *Timings) Histograms() (h map[string]*Histogram) {
 	return
 }
 
+// NativeHistograms returns a map pointing at the native (exponential)
+// histograms, for Timings created with NewExponentialTimings. It is empty
+// for Timings using the fixed bucketCutoffs.
+func (t *Timings) NativeHistograms() (h map[string]*NativeHistogram) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	h = make(map[string]*NativeHistogram, len(t.nativeHistograms))
+	for k, v := range t.nativeHistograms {
+		h[k] = v
+	}
+	return
+}
+
 // Count returns the total count for all values.
 func (t *Timings) Count() int64 {
 	return t.totalCount.Load()
@@ -223,6 +301,36 @@ func NewMultiTimings(name string, help string, labels []string) *MultiTimings {
 	return t
 }
 
+// NewExponentialMultiTimings creates a new MultiTimings object whose
+// per-category histograms are Prometheus-style native (exponential)
+// histograms instead of the fixed bucketCutoffs used by NewMultiTimings.
+// See NewExponentialTimings for the meaning of schema and maxBuckets.
+func NewExponentialMultiTimings(name, help string, labels []string, schema int8, maxBuckets int) *MultiTimings {
+	combinedLabels := make([]bool, len(labels))
+	for i, label := range labels {
+		combinedLabels[i] = IsDimensionCombined(label)
+	}
+	mt := &MultiTimings{
+		Timings: Timings{
+			histograms:       make(map[string]*Histogram),
+			native:           true,
+			nativeSchema:     schema,
+			nativeMaxBuckets: maxBuckets,
+			nativeHistograms: make(map[string]*NativeHistogram),
+			name:             name,
+			help:             help,
+			label:            safeJoinLabels(labels, combinedLabels),
+		},
+		labels:         labels,
+		combinedLabels: combinedLabels,
+	}
+	if name != "" {
+		publish(name, mt)
+	}
+
+	return mt
+}
+
 // Labels returns descriptions of the parts of each compound category name.
 func (mt *MultiTimings) Labels() []string {
 	return mt.labels
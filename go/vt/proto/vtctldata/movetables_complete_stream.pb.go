@@ -0,0 +1,77 @@
+// This file is a hand-authored placeholder for the Go type that
+// `protoc --go_out` would generate from the MoveTablesCompleteStreamResponse
+// addition to vtctldata.proto (see that file for the message definition).
+// The real vtctldata.pb.go in this tree is not available to regenerate
+// here, so this stands in for it until `make proto` is run for real.
+//
+// It is NOT a drop-in replacement: protoc-gen-go output implements
+// proto.Message via protoreflect (a generated descriptor plus
+// protoimpl.MessageState), which is what the grpc codec actually calls
+// into on the wire. The Reset/String/ProtoMessage methods below only
+// satisfy the pre-protoreflect proto.Message shape, so stream.Send/Recv
+// will not round-trip this type correctly until the real generated code
+// replaces it.
+package vtctldata
+
+import (
+	vttimepb "vitess.io/vitess/go/vt/proto/vttime"
+)
+
+// MoveTablesCompleteStreamResponse_EventType enumerates the phases of a
+// MoveTables completion. MoveTablesCompleteStream sends PHASE_STARTED and
+// COMPLETED; it does not send TABLE_RENAMED, ROUTING_RULE_UPDATED,
+// SHARD_FLUSHED or DRY_RUN_STEP events.
+type MoveTablesCompleteStreamResponse_EventType int32
+
+const (
+	MoveTablesCompleteStreamResponse_PHASE_STARTED        MoveTablesCompleteStreamResponse_EventType = 0
+	MoveTablesCompleteStreamResponse_TABLE_RENAMED        MoveTablesCompleteStreamResponse_EventType = 1
+	MoveTablesCompleteStreamResponse_ROUTING_RULE_UPDATED MoveTablesCompleteStreamResponse_EventType = 2
+	MoveTablesCompleteStreamResponse_SHARD_FLUSHED        MoveTablesCompleteStreamResponse_EventType = 3
+	MoveTablesCompleteStreamResponse_DRY_RUN_STEP         MoveTablesCompleteStreamResponse_EventType = 4
+	MoveTablesCompleteStreamResponse_COMPLETED            MoveTablesCompleteStreamResponse_EventType = 5
+)
+
+var moveTablesCompleteStreamResponseEventTypeNames = map[MoveTablesCompleteStreamResponse_EventType]string{
+	MoveTablesCompleteStreamResponse_PHASE_STARTED:        "PHASE_STARTED",
+	MoveTablesCompleteStreamResponse_TABLE_RENAMED:        "TABLE_RENAMED",
+	MoveTablesCompleteStreamResponse_ROUTING_RULE_UPDATED: "ROUTING_RULE_UPDATED",
+	MoveTablesCompleteStreamResponse_SHARD_FLUSHED:        "SHARD_FLUSHED",
+	MoveTablesCompleteStreamResponse_DRY_RUN_STEP:         "DRY_RUN_STEP",
+	MoveTablesCompleteStreamResponse_COMPLETED:            "COMPLETED",
+}
+
+// String is part of the fmt.Stringer interface, implemented the way
+// protoc-gen-go implements it for proto3 enums.
+func (t MoveTablesCompleteStreamResponse_EventType) String() string {
+	if name, ok := moveTablesCompleteStreamResponseEventTypeNames[t]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// MoveTablesCompleteStreamResponse is one progress event emitted by the
+// MoveTablesCompleteStream RPC. Sequence increases monotonically for a
+// given stream so that clients (and NDJSON consumers) can detect gaps or
+// reordering.
+type MoveTablesCompleteStreamResponse struct {
+	Sequence  uint64
+	Timestamp *vttimepb.Time
+	Type      MoveTablesCompleteStreamResponse_EventType
+	Message   string
+
+	// Summary and DryRunResults are only populated on the terminal
+	// COMPLETED event, and mirror MoveTablesCompleteResponse.
+	Summary       string
+	DryRunResults []string
+}
+
+// Reset, String and ProtoMessage implement proto.Message the way
+// protoc-gen-go implements it for generated message types.
+func (x *MoveTablesCompleteStreamResponse) Reset() { *x = MoveTablesCompleteStreamResponse{} }
+
+func (x *MoveTablesCompleteStreamResponse) String() string {
+	return "MoveTablesCompleteStreamResponse"
+}
+
+func (*MoveTablesCompleteStreamResponse) ProtoMessage() {}
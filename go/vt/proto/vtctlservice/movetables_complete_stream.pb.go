@@ -0,0 +1,102 @@
+// This file is a hand-authored placeholder standing in for what
+// `protoc-gen-go-grpc` would add to vtctlservice.pb.go for the
+// MoveTablesCompleteStream addition (see vtctlservice.proto). The real
+// vtctlservice.pb.go is not available to regenerate in this tree, so this
+// is NOT wired into the real VtctldClient/VtctldServer interfaces or the
+// service's grpc.ServiceDesc -- that still needs to happen once `make
+// proto` can run for real:
+//
+//   // VtctldClient gains:
+//   MoveTablesCompleteStream(ctx context.Context, in *vtctldata.MoveTablesCompleteRequest, opts ...grpc.CallOption) (Vtctld_MoveTablesCompleteStreamClient, error)
+//
+//   // VtctldServer gains:
+//   MoveTablesCompleteStream(*vtctldata.MoveTablesCompleteRequest, Vtctld_MoveTablesCompleteStreamServer) error
+//
+//   // the service's grpc.ServiceDesc.Streams gains a grpc.StreamDesc
+//   // pointing at VtctldMoveTablesCompleteStreamHandler below.
+//
+// The stream wrapper types and NewVtctldMoveTablesCompleteStreamClient
+// helper below are the net-new pieces that addition needs, written the
+// same way every other streaming RPC's generated code on this service is.
+
+package vtctlservice
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+const vtctldMoveTablesCompleteStreamFullMethod = "/vtctlservice.Vtctld/MoveTablesCompleteStream"
+
+// Vtctld_MoveTablesCompleteStreamClient is implemented by the client side
+// of the MoveTablesCompleteStream streaming RPC.
+type Vtctld_MoveTablesCompleteStreamClient interface {
+	Recv() (*vtctldatapb.MoveTablesCompleteStreamResponse, error)
+	grpc.ClientStream
+}
+
+type vtctldMoveTablesCompleteStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *vtctldMoveTablesCompleteStreamClient) Recv() (*vtctldatapb.MoveTablesCompleteStreamResponse, error) {
+	m := new(vtctldatapb.MoveTablesCompleteStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewVtctldMoveTablesCompleteStreamClient opens the client side of the
+// MoveTablesCompleteStream RPC against an existing *grpc.ClientConn. It is
+// the helper gRPCVtctldClient.MoveTablesCompleteStream in grpcvtctldclient
+// calls into.
+func NewVtctldMoveTablesCompleteStreamClient(ctx context.Context, cc grpc.ClientConnInterface, in *vtctldatapb.MoveTablesCompleteRequest, opts ...grpc.CallOption) (Vtctld_MoveTablesCompleteStreamClient, error) {
+	stream, err := cc.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    "MoveTablesCompleteStream",
+		ServerStreams: true,
+	}, vtctldMoveTablesCompleteStreamFullMethod, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &vtctldMoveTablesCompleteStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Vtctld_MoveTablesCompleteStreamServer is implemented by the server side
+// of the MoveTablesCompleteStream streaming RPC.
+type Vtctld_MoveTablesCompleteStreamServer interface {
+	Send(*vtctldatapb.MoveTablesCompleteStreamResponse) error
+	grpc.ServerStream
+}
+
+type vtctldMoveTablesCompleteStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *vtctldMoveTablesCompleteStreamServer) Send(m *vtctldatapb.MoveTablesCompleteStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// VtctldMoveTablesCompleteStreamHandler adapts a VtctldServer's
+// MoveTablesCompleteStream implementation to a grpc.StreamHandler, the way
+// protoc-gen-go-grpc wires every streaming RPC into the service's
+// grpc.ServiceDesc.
+func VtctldMoveTablesCompleteStreamHandler(srv interface {
+	MoveTablesCompleteStream(*vtctldatapb.MoveTablesCompleteRequest, Vtctld_MoveTablesCompleteStreamServer) error
+}, stream grpc.ServerStream) error {
+	m := new(vtctldatapb.MoveTablesCompleteRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.MoveTablesCompleteStream(m, &vtctldMoveTablesCompleteStreamServer{stream})
+}
@@ -0,0 +1,613 @@
+/*
+Copyright 2019 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+)
+
+// WriteMode controls how a MultiTee fans a write out across its backends.
+type WriteMode int
+
+const (
+	// WriteAll requires every backend (the primary and all secondaries)
+	// to acknowledge a write synchronously. The operation fails if any
+	// one of them fails.
+	WriteAll WriteMode = iota
+	// WritePrimaryThenAsync writes to the primary synchronously and
+	// queues the write to each secondary on a bounded background worker
+	// pool with retries. A secondary that keeps failing is recorded to a
+	// dead-letter counter instead of blocking the caller.
+	WritePrimaryThenAsync
+	// WriteQuorum succeeds once WriteQuorum backends, counting the
+	// primary, have acknowledged the write.
+	WriteQuorum
+)
+
+// ReadMode controls how a MultiTee fans a read out across its backends.
+type ReadMode int
+
+const (
+	// ReadPrimary always reads from the primary backend.
+	ReadPrimary ReadMode = iota
+	// ReadQuorum reads from ReadQuorum backends, counting the primary,
+	// and returns the value with the highest version observed.
+	ReadQuorum
+	// ReadRoundRobin spreads reads across all backends, weighted by
+	// BackendOptions.Weight, and read-repairs any backend found to be
+	// behind the primary by writing the primary's value back to it.
+	ReadRoundRobin
+)
+
+// BackendOptions describes one secondary backend passed to NewMultiTee.
+type BackendOptions struct {
+	// Name identifies this backend in the exported stats.Timings labels,
+	// e.g. "etcd-eu" or "consul-standby". Defaults to "secondary<index>"
+	// if empty.
+	Name string
+	// Weight biases ReadRoundRobin selection towards higher-weighted
+	// backends. It has no effect on WriteMode or ReadPrimary/ReadQuorum.
+	// Defaults to 1.
+	Weight int
+}
+
+// MultiTeeOptions configures NewMultiTee.
+type MultiTeeOptions struct {
+	WriteMode WriteMode
+	// WriteQuorum is the number of acks required (including the primary)
+	// when WriteMode is WriteQuorum. It is clamped to
+	// [1, 1+len(secondaries)].
+	WriteQuorum int
+	// AsyncWorkers is the size of the background worker pool used by
+	// WritePrimaryThenAsync, per secondary. Defaults to 4.
+	AsyncWorkers int
+	// AsyncQueueSize bounds the number of writes queued per secondary
+	// before new async writes block the caller. Defaults to 1000.
+	AsyncQueueSize int
+	// AsyncMaxRetries is the number of retries for an async write before
+	// it is recorded as dead-lettered. Defaults to 5.
+	AsyncMaxRetries int
+
+	ReadMode ReadMode
+	// ReadQuorum is the number of backends read (including the primary)
+	// when ReadMode is ReadQuorum. It is clamped to
+	// [1, 1+len(secondaries)].
+	ReadQuorum int
+
+	// Backends describes the secondaries passed to NewMultiTee, in the
+	// same order as the secondaries slice. Entries missing relative to
+	// secondaries default to Weight 1 and a name derived from their
+	// index.
+	Backends []BackendOptions
+}
+
+// multiTeeTimings exports per-backend, per-operation latency so operators
+// can see which secondary in a MultiTee is lagging or erroring.
+var multiTeeTimings = stats.NewMultiTimings(
+	"TopoMultiTeeOperations",
+	"Timings of topo MultiTee backend operations, broken down by backend name and operation",
+	[]string{"Backend", "Operation"},
+)
+
+// multiTeeDeadLetters counts async writes that were abandoned after
+// exhausting their retries under WritePrimaryThenAsync.
+var multiTeeDeadLetters = stats.NewCountersWithMultiLabels(
+	"TopoMultiTeeDeadLetters",
+	"Count of async MultiTee writes abandoned after exhausting retries, by backend and operation",
+	[]string{"Backend", "Operation"},
+)
+
+// multiTeeBackend pairs a topo.Conn with the metadata needed for stats,
+// quorum and read-repair bookkeeping.
+type multiTeeBackend struct {
+	name   string
+	weight int
+	conn   topo.Conn
+
+	asyncCh chan multiTeeAsyncJob
+}
+
+type multiTeeAsyncJob struct {
+	op string
+	do func(ctx context.Context, conn topo.Conn) (topo.Version, error)
+}
+
+// multiTeeConn is a topo.Conn that fans reads and writes out across a
+// primary and N secondary topo.Conns according to MultiTeeOptions. It
+// generalizes the two-backend Conn used by NewTee so that operators can
+// run a migration (e.g. etcd -> consul -> zk) across more than one
+// secondary at a time and verify parity before decommissioning the old
+// store.
+//
+// Cluster-coordination primitives (Lock*, Watch*, NewLeaderParticipation)
+// are always served by the primary: reconciling locks or leader elections
+// across heterogeneous stores isn't meaningful, and every caller needs a
+// single source of truth for them regardless of WriteMode/ReadMode.
+type multiTeeConn struct {
+	cell string
+
+	primary     multiTeeBackend
+	secondaries []multiTeeBackend
+
+	opts MultiTeeOptions
+
+	rrCounter atomic.Uint64
+
+	// closeMu guards against sending to a secondary's asyncCh concurrently
+	// with Close() closing it: dispatchWrite holds the read side while it
+	// sends, Close() takes the write side so no send can be in flight when
+	// the channels are closed.
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// NewMultiTee creates a topo.Server that fans reads and writes out across
+// primary and secondaries according to opts. At least one secondary must
+// be given.
+func NewMultiTee(primary *topo.Server, secondaries []*topo.Server, opts MultiTeeOptions) (*topo.Server, error) {
+	if len(secondaries) == 0 {
+		return nil, fmt.Errorf("multitee: at least one secondary backend is required")
+	}
+
+	opts.WriteQuorum = clampInt(opts.WriteQuorum, 1, 1+len(secondaries))
+	opts.ReadQuorum = clampInt(opts.ReadQuorum, 1, 1+len(secondaries))
+
+	return topo.NewWithFactory(&multiTeeFactory{
+		primary:     primary,
+		secondaries: secondaries,
+		opts:        opts,
+	}, "", "")
+}
+
+type multiTeeFactory struct {
+	primary     *topo.Server
+	secondaries []*topo.Server
+	opts        MultiTeeOptions
+}
+
+// HasGlobalReadOnlyCell is part of the topo.Factory interface.
+func (f *multiTeeFactory) HasGlobalReadOnlyCell(serverAddr, root string) bool {
+	return false
+}
+
+// Create is part of the topo.Factory interface.
+func (f *multiTeeFactory) Create(cell, serverAddr, root string) (topo.Conn, error) {
+	ctx := context.Background()
+
+	primaryConn, err := f.primary.ConnForCell(ctx, cell)
+	if err != nil {
+		return nil, err
+	}
+
+	backends := make([]multiTeeBackend, len(f.secondaries))
+	for i, s := range f.secondaries {
+		conn, err := s.ConnForCell(ctx, cell)
+		if err != nil {
+			return nil, err
+		}
+		backends[i] = multiTeeBackend{
+			name:   f.backendName(i),
+			weight: f.backendWeight(i),
+			conn:   conn,
+		}
+	}
+
+	c := &multiTeeConn{
+		cell:        cell,
+		primary:     multiTeeBackend{name: "primary", weight: 1, conn: primaryConn},
+		secondaries: backends,
+		opts:        f.opts,
+	}
+	if f.opts.WriteMode == WritePrimaryThenAsync {
+		c.startAsyncWorkers()
+	}
+	return c, nil
+}
+
+func (f *multiTeeFactory) backendName(i int) string {
+	if i < len(f.opts.Backends) && f.opts.Backends[i].Name != "" {
+		return f.opts.Backends[i].Name
+	}
+	return fmt.Sprintf("secondary%d", i)
+}
+
+func (f *multiTeeFactory) backendWeight(i int) int {
+	if i < len(f.opts.Backends) && f.opts.Backends[i].Weight > 0 {
+		return f.opts.Backends[i].Weight
+	}
+	return 1
+}
+
+func (c *multiTeeConn) startAsyncWorkers() {
+	workers := c.opts.AsyncWorkers
+	if workers <= 0 {
+		workers = 4
+	}
+	queueSize := c.opts.AsyncQueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	for i := range c.secondaries {
+		s := &c.secondaries[i]
+		s.asyncCh = make(chan multiTeeAsyncJob, queueSize)
+		for w := 0; w < workers; w++ {
+			go c.asyncWorker(s)
+		}
+	}
+}
+
+func (c *multiTeeConn) asyncWorker(s *multiTeeBackend) {
+	maxRetries := c.opts.AsyncMaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 5
+	}
+	for job := range s.asyncCh {
+		var err error
+		for attempt := 0; attempt <= maxRetries; attempt++ {
+			start := time.Now()
+			_, err = job.do(context.Background(), s.conn)
+			multiTeeTimings.Record([]string{s.name, job.op}, start)
+			if err == nil {
+				break
+			}
+			time.Sleep(asyncBackoff(attempt))
+		}
+		if err != nil {
+			multiTeeDeadLetters.Add([]string{s.name, job.op}, 1)
+			log.Errorf("multitee: giving up on async %s to backend %s after %d attempts: %v", job.op, s.name, maxRetries+1, err)
+		}
+	}
+}
+
+func asyncBackoff(attempt int) time.Duration {
+	d := time.Duration(1<<uint(attempt)) * 50 * time.Millisecond
+	if d > 5*time.Second {
+		d = 5 * time.Second
+	}
+	return d
+}
+
+// dispatchWrite runs do against the primary synchronously, then fans it
+// out to the secondaries according to c.opts.WriteMode.
+func (c *multiTeeConn) dispatchWrite(ctx context.Context, op string, do func(ctx context.Context, conn topo.Conn) (topo.Version, error)) (topo.Version, error) {
+	start := time.Now()
+	ver, err := do(ctx, c.primary.conn)
+	multiTeeTimings.Record([]string{c.primary.name, op}, start)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.opts.WriteMode {
+	case WriteAll:
+		for _, s := range c.secondaries {
+			sStart := time.Now()
+			_, sErr := do(ctx, s.conn)
+			multiTeeTimings.Record([]string{s.name, op}, sStart)
+			if sErr != nil {
+				return nil, fmt.Errorf("multitee: backend %s failed %s: %w", s.name, op, sErr)
+			}
+		}
+
+	case WriteQuorum:
+		// Every secondary is written to -- skipping stragglers once the
+		// quorum is reached would mean they silently never converge,
+		// defeating the point of tracking parity across them. Only the
+		// quorum requirement gates success/failure of the call.
+		need := c.opts.WriteQuorum - 1 // the primary ack above already counts as one.
+		results := make(chan error, len(c.secondaries))
+		for _, s := range c.secondaries {
+			s := s
+			go func() {
+				sStart := time.Now()
+				_, sErr := do(ctx, s.conn)
+				multiTeeTimings.Record([]string{s.name, op}, sStart)
+				results <- sErr
+			}()
+		}
+		acked := 0
+		var lastErr error
+		for range c.secondaries {
+			if sErr := <-results; sErr != nil {
+				lastErr = sErr
+			} else {
+				acked++
+			}
+		}
+		if acked < need {
+			return nil, fmt.Errorf("multitee: quorum of %d not reached for %s: %w", c.opts.WriteQuorum, op, lastErr)
+		}
+
+	case WritePrimaryThenAsync:
+		c.closeMu.RLock()
+		if c.closed {
+			c.closeMu.RUnlock()
+			break
+		}
+		for i := range c.secondaries {
+			c.secondaries[i].asyncCh <- multiTeeAsyncJob{op: op, do: do}
+		}
+		c.closeMu.RUnlock()
+	}
+
+	return ver, nil
+}
+
+// Create is part of the topo.Conn interface.
+func (c *multiTeeConn) Create(ctx context.Context, filePath string, contents []byte) (topo.Version, error) {
+	return c.dispatchWrite(ctx, "Create", func(ctx context.Context, conn topo.Conn) (topo.Version, error) {
+		return conn.Create(ctx, filePath, contents)
+	})
+}
+
+// Update is part of the topo.Conn interface.
+func (c *multiTeeConn) Update(ctx context.Context, filePath string, contents []byte, version topo.Version) (topo.Version, error) {
+	return c.dispatchWrite(ctx, "Update", func(ctx context.Context, conn topo.Conn) (topo.Version, error) {
+		return conn.Update(ctx, filePath, contents, version)
+	})
+}
+
+// Delete is part of the topo.Conn interface.
+func (c *multiTeeConn) Delete(ctx context.Context, filePath string, version topo.Version) error {
+	_, err := c.dispatchWrite(ctx, "Delete", func(ctx context.Context, conn topo.Conn) (topo.Version, error) {
+		return nil, conn.Delete(ctx, filePath, version)
+	})
+	return err
+}
+
+// Get is part of the topo.Conn interface.
+func (c *multiTeeConn) Get(ctx context.Context, filePath string) ([]byte, topo.Version, error) {
+	switch c.opts.ReadMode {
+	case ReadQuorum:
+		return c.getQuorum(ctx, filePath)
+	case ReadRoundRobin:
+		return c.getRoundRobin(ctx, filePath)
+	default:
+		start := time.Now()
+		data, ver, err := c.primary.conn.Get(ctx, filePath)
+		multiTeeTimings.Record([]string{c.primary.name, "Get"}, start)
+		return data, ver, err
+	}
+}
+
+// getQuorum reads from up to ReadQuorum backends (primary first, then
+// secondaries in order) and returns the value with the highest version
+// seen, so that a stale backend that still acks doesn't shadow fresher
+// data held by another.
+func (c *multiTeeConn) getQuorum(ctx context.Context, filePath string) ([]byte, topo.Version, error) {
+	type result struct {
+		backend string
+		data    []byte
+		ver     topo.Version
+		err     error
+	}
+
+	targets := append([]multiTeeBackend{c.primary}, c.secondaries...)
+	if len(targets) > c.opts.ReadQuorum {
+		targets = targets[:c.opts.ReadQuorum]
+	}
+
+	results := make([]result, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t multiTeeBackend) {
+			defer wg.Done()
+			start := time.Now()
+			data, ver, err := t.conn.Get(ctx, filePath)
+			multiTeeTimings.Record([]string{t.name, "Get"}, start)
+			results[i] = result{backend: t.name, data: data, ver: ver, err: err}
+		}(i, t)
+	}
+	wg.Wait()
+
+	var best *result
+	var lastErr error
+	for i := range results {
+		r := &results[i]
+		if r.err != nil {
+			lastErr = r.err
+			continue
+		}
+		if best == nil || versionOf(r.ver) > versionOf(best.ver) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, nil, fmt.Errorf("multitee: no backend in read quorum answered Get(%s): %w", filePath, lastErr)
+	}
+	return best.data, best.ver, nil
+}
+
+// getRoundRobin picks a backend weighted by BackendOptions.Weight, reads
+// from it, and read-repairs it if the primary holds a newer version.
+func (c *multiTeeConn) getRoundRobin(ctx context.Context, filePath string) ([]byte, topo.Version, error) {
+	target := c.pickRoundRobin()
+
+	start := time.Now()
+	data, ver, err := target.conn.Get(ctx, filePath)
+	multiTeeTimings.Record([]string{target.name, "Get"}, start)
+	if target.name == c.primary.name {
+		return data, ver, err
+	}
+
+	primaryStart := time.Now()
+	primaryData, primaryVer, primaryErr := c.primary.conn.Get(ctx, filePath)
+	multiTeeTimings.Record([]string{c.primary.name, "Get"}, primaryStart)
+	if primaryErr != nil {
+		// The primary is the source of truth for repair, but if it's
+		// unavailable there's nothing to reconcile against; serve what
+		// the selected backend returned.
+		return data, ver, err
+	}
+
+	if err != nil || versionOf(primaryVer) > versionOf(ver) {
+		go c.readRepair(target, filePath, primaryData, primaryVer)
+		return primaryData, primaryVer, primaryErr
+	}
+	return data, ver, err
+}
+
+// readRepair writes the primary's data to a stale secondary. It always
+// reads the secondary's own current version first and passes that back to
+// Update as the CAS token, rather than a nil version: whether nil means
+// "unconditional write" or "expect no prior write" isn't something every
+// topo.Conn backend is guaranteed to agree on, but every implementation
+// has to support a standard check-and-set against a version Get just
+// returned, since that's the operation topo.Conn's other callers (locks,
+// leader election) already depend on. A mismatch here just means someone
+// else repaired or wrote the same key concurrently, so it's logged and
+// left for the next read to reconcile rather than retried.
+func (c *multiTeeConn) readRepair(target multiTeeBackend, filePath string, data []byte, version topo.Version) {
+	ctx := context.Background()
+	start := time.Now()
+	_, curVer, err := target.conn.Get(ctx, filePath)
+	if err != nil {
+		_, err = target.conn.Create(ctx, filePath, data)
+	} else {
+		_, err = target.conn.Update(ctx, filePath, data, curVer)
+	}
+	multiTeeTimings.Record([]string{target.name, "ReadRepair"}, start)
+	if err != nil {
+		log.Warningf("multitee: read-repair of %s on backend %s failed: %v", filePath, target.name, err)
+	}
+}
+
+func (c *multiTeeConn) pickRoundRobin() multiTeeBackend {
+	all := append([]multiTeeBackend{c.primary}, c.secondaries...)
+	totalWeight := 0
+	for _, b := range all {
+		totalWeight += b.weight
+	}
+	if totalWeight <= 0 {
+		return c.primary
+	}
+
+	n := int(c.rrCounter.Add(1)) % totalWeight
+	for _, b := range all {
+		if n < b.weight {
+			return b
+		}
+		n -= b.weight
+	}
+	return c.primary
+}
+
+// versionOf extracts a comparable integer from a topo.Version, falling
+// back to 0 if it isn't numeric (e.g. a nil version, or a backend using
+// opaque version tokens that can't be ordered this way).
+func versionOf(v topo.Version) int64 {
+	if v == nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(v.String(), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetVersion is part of the topo.Conn interface. It always reads the
+// primary: reconciling a specific historical version across backends that
+// may have compacted their history differently isn't meaningful.
+func (c *multiTeeConn) GetVersion(ctx context.Context, filePath string, version int64) ([]byte, error) {
+	return c.primary.conn.GetVersion(ctx, filePath, version)
+}
+
+// List is part of the topo.Conn interface. It always reads the primary:
+// reconciling a whole prefix listing across backends is out of scope for
+// the read modes implemented here.
+func (c *multiTeeConn) List(ctx context.Context, filePathPrefix string) ([]topo.KVInfo, error) {
+	return c.primary.conn.List(ctx, filePathPrefix)
+}
+
+// ListDir is part of the topo.Conn interface; see List.
+func (c *multiTeeConn) ListDir(ctx context.Context, dirPath string, full bool) ([]topo.DirEntry, error) {
+	return c.primary.conn.ListDir(ctx, dirPath, full)
+}
+
+// Lock is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) Lock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	return c.primary.conn.Lock(ctx, dirPath, contents)
+}
+
+// LockWithTTL is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) LockWithTTL(ctx context.Context, dirPath, contents string, ttl time.Duration) (topo.LockDescriptor, error) {
+	return c.primary.conn.LockWithTTL(ctx, dirPath, contents, ttl)
+}
+
+// LockName is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) LockName(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	return c.primary.conn.LockName(ctx, dirPath, contents)
+}
+
+// TryLock is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) TryLock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	return c.primary.conn.TryLock(ctx, dirPath, contents)
+}
+
+// Watch is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) Watch(ctx context.Context, filePath string) (*topo.WatchData, <-chan *topo.WatchData, error) {
+	return c.primary.conn.Watch(ctx, filePath)
+}
+
+// WatchRecursive is part of the topo.Conn interface; always served by the primary.
+func (c *multiTeeConn) WatchRecursive(ctx context.Context, path string) ([]*topo.WatchDataRecursive, <-chan *topo.WatchDataRecursive, error) {
+	return c.primary.conn.WatchRecursive(ctx, path)
+}
+
+// NewLeaderParticipation is part of the topo.Conn interface; always served
+// by the primary, since leader election must have a single source of truth.
+func (c *multiTeeConn) NewLeaderParticipation(name, id string) (topo.LeaderParticipation, error) {
+	return c.primary.conn.NewLeaderParticipation(name, id)
+}
+
+// Close is part of the topo.Conn interface. Taking closeMu for writing
+// ensures no dispatchWrite call is still sending to a secondary's asyncCh
+// when we close it below.
+func (c *multiTeeConn) Close() {
+	c.closeMu.Lock()
+	c.closed = true
+	c.closeMu.Unlock()
+
+	c.primary.conn.Close()
+	for i := range c.secondaries {
+		s := &c.secondaries[i]
+		if s.asyncCh != nil {
+			close(s.asyncCh)
+		}
+		s.conn.Close()
+	}
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2019 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/topo/test"
+)
+
+func TestMultiTeeTopoWriteAll(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	test.TopoServerTestSuite(t, ctx, func() *topo.Server {
+		primary := memorytopo.NewServer(ctx, test.LocalCellName)
+		s2 := memorytopo.NewServer(ctx, test.LocalCellName)
+		s3 := memorytopo.NewServer(ctx, test.LocalCellName)
+
+		tee, err := NewMultiTee(primary, []*topo.Server{s2, s3}, MultiTeeOptions{
+			WriteMode: WriteAll,
+			ReadMode:  ReadPrimary,
+			Backends: []BackendOptions{
+				{Name: "secondary-a"},
+				{Name: "secondary-b"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewMultiTee() failed: %v", err)
+		}
+		return tee
+	}, []string{"checkTryLock", "checkShardWithLock"})
+}
+
+func TestMultiTeeTopoWriteQuorum(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	test.TopoServerTestSuite(t, ctx, func() *topo.Server {
+		primary := memorytopo.NewServer(ctx, test.LocalCellName)
+		s2 := memorytopo.NewServer(ctx, test.LocalCellName)
+		s3 := memorytopo.NewServer(ctx, test.LocalCellName)
+
+		tee, err := NewMultiTee(primary, []*topo.Server{s2, s3}, MultiTeeOptions{
+			WriteMode:   WriteQuorum,
+			WriteQuorum: 2,
+			ReadMode:    ReadQuorum,
+			ReadQuorum:  2,
+		})
+		if err != nil {
+			t.Fatalf("NewMultiTee() failed: %v", err)
+		}
+		return tee
+	}, []string{"checkTryLock", "checkShardWithLock"})
+}
+
+func TestMultiTeeTopoWritePrimaryThenAsync(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	test.TopoServerTestSuite(t, ctx, func() *topo.Server {
+		primary := memorytopo.NewServer(ctx, test.LocalCellName)
+		s2 := memorytopo.NewServer(ctx, test.LocalCellName)
+
+		tee, err := NewMultiTee(primary, []*topo.Server{s2}, MultiTeeOptions{
+			WriteMode:    WritePrimaryThenAsync,
+			ReadMode:     ReadPrimary,
+			AsyncWorkers: 2,
+		})
+		if err != nil {
+			t.Fatalf("NewMultiTee() failed: %v", err)
+		}
+		return tee
+	}, []string{"checkTryLock", "checkShardWithLock"})
+}
+
+func TestMultiTeeTopoReadRoundRobin(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	test.TopoServerTestSuite(t, ctx, func() *topo.Server {
+		primary := memorytopo.NewServer(ctx, test.LocalCellName)
+		s2 := memorytopo.NewServer(ctx, test.LocalCellName)
+
+		// WriteAll pairs with ReadRoundRobin here because every backend
+		// is guaranteed consistent by the time a write returns, which is
+		// what the generic suite expects from reads right after writes.
+		tee, err := NewMultiTee(primary, []*topo.Server{s2}, MultiTeeOptions{
+			WriteMode: WriteAll,
+			ReadMode:  ReadRoundRobin,
+			Backends: []BackendOptions{
+				{Name: "secondary", Weight: 1},
+			},
+		})
+		if err != nil {
+			t.Fatalf("NewMultiTee() failed: %v", err)
+		}
+		return tee
+	}, []string{"checkTryLock", "checkShardWithLock"})
+}
+
+// TestMultiTeeWritePrimaryThenAsyncClosesCleanly exercises the async
+// worker pool end to end: a write must eventually reach the secondary,
+// and closing the conn must tear the workers down rather than leaking
+// them, which utils.LeakCheckContext below asserts on test exit.
+func TestMultiTeeWritePrimaryThenAsyncClosesCleanly(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	primary := memorytopo.NewServer(ctx, test.LocalCellName)
+	secondary := memorytopo.NewServer(ctx, test.LocalCellName)
+
+	tee, err := NewMultiTee(primary, []*topo.Server{secondary}, MultiTeeOptions{
+		WriteMode:    WritePrimaryThenAsync,
+		AsyncWorkers: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewMultiTee() failed: %v", err)
+	}
+
+	conn, err := tee.ConnForCell(ctx, test.LocalCellName)
+	if err != nil {
+		t.Fatalf("ConnForCell() failed: %v", err)
+	}
+	if _, err := conn.Create(ctx, "/asyncpath", []byte("hello")); err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	secondaryConn, err := secondary.ConnForCell(ctx, test.LocalCellName)
+	if err != nil {
+		t.Fatalf("ConnForCell() on secondary failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _, getErr := secondaryConn.Get(ctx, "/asyncpath")
+		if getErr == nil && string(data) == "hello" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("async write never reached secondary: %v", getErr)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	conn.Close()
+}
+
+// TestMultiTeeReadRoundRobinReadRepair makes the secondary go stale behind
+// the tee's back, then checks that reads through the tee always observe
+// the primary's latest value and that read-repair eventually catches the
+// secondary up.
+func TestMultiTeeReadRoundRobinReadRepair(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	primary := memorytopo.NewServer(ctx, test.LocalCellName)
+	secondary := memorytopo.NewServer(ctx, test.LocalCellName)
+
+	tee, err := NewMultiTee(primary, []*topo.Server{secondary}, MultiTeeOptions{
+		WriteMode: WriteAll,
+		ReadMode:  ReadRoundRobin,
+		Backends: []BackendOptions{
+			{Name: "secondary", Weight: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewMultiTee() failed: %v", err)
+	}
+
+	conn, err := tee.ConnForCell(ctx, test.LocalCellName)
+	if err != nil {
+		t.Fatalf("ConnForCell() failed: %v", err)
+	}
+	defer conn.Close()
+
+	ver, err := conn.Create(ctx, "/repairpath", []byte("v1"))
+	if err != nil {
+		t.Fatalf("Create() failed: %v", err)
+	}
+
+	// Update only the primary directly, behind the tee's back, so the
+	// secondary is left holding a stale version -- the situation
+	// ReadRoundRobin's read-repair is meant to fix.
+	primaryConn, err := primary.ConnForCell(ctx, test.LocalCellName)
+	if err != nil {
+		t.Fatalf("ConnForCell() on primary failed: %v", err)
+	}
+	if _, err := primaryConn.Update(ctx, "/repairpath", []byte("v2"), ver); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	// Whichever backend ReadRoundRobin happens to pick, every read
+	// through the tee must still observe the primary's latest value.
+	for i := 0; i < 10; i++ {
+		data, _, err := conn.Get(ctx, "/repairpath")
+		if err != nil {
+			t.Fatalf("Get() failed: %v", err)
+		}
+		if string(data) != "v2" {
+			t.Fatalf("Get() = %q, want %q", data, "v2")
+		}
+	}
+
+	secondaryConn, err := secondary.ConnForCell(ctx, test.LocalCellName)
+	if err != nil {
+		t.Fatalf("ConnForCell() on secondary failed: %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		data, _, getErr := secondaryConn.Get(ctx, "/repairpath")
+		if getErr == nil && string(data) == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("read-repair never caught up the secondary: %v", getErr)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewMultiTeeRequiresASecondary(t *testing.T) {
+	ctx := utils.LeakCheckContext(t)
+	primary := memorytopo.NewServer(ctx, test.LocalCellName)
+
+	if _, err := NewMultiTee(primary, nil, MultiTeeOptions{}); err == nil {
+		t.Fatal("NewMultiTee() with no secondaries should have failed")
+	}
+}
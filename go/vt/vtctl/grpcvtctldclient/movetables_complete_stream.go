@@ -0,0 +1,37 @@
+/*
+Copyright 2019 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcvtctldclient
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtctlservicepb "vitess.io/vitess/go/vt/proto/vtctlservice"
+)
+
+// MoveTablesCompleteStream is the client side of the server-streaming
+// MoveTablesCompleteStream RPC, implemented the same way every other
+// streaming RPC on gRPCVtctldClient is: by opening the stream directly on
+// the underlying *grpc.ClientConn.
+func (client *gRPCVtctldClient) MoveTablesCompleteStream(ctx context.Context, in *vtctldatapb.MoveTablesCompleteRequest, opts ...grpc.CallOption) (vtctlservicepb.Vtctld_MoveTablesCompleteStreamClient, error) {
+	if client.cc == nil {
+		return nil, errClientClosed
+	}
+	return vtctlservicepb.NewVtctldMoveTablesCompleteStreamClient(ctx, client.cc, in, opts...)
+}